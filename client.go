@@ -0,0 +1,679 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type NotDefinedError string
+
+func (suffix NotDefinedError) Error() string {
+	return fmt.Sprintf("metadata: GCE metadata %q not defined", string(suffix))
+}
+
+// ErrMetadataUnavailable is returned when the metadata server could not
+// be reached after retrying, for example because it kept returning 5xx
+// responses or the network call itself kept failing. Unlike
+// NotDefinedError, it does not mean the server was reached and the key
+// was confirmed absent.
+var ErrMetadataUnavailable = errors.New("metadata: GCE metadata server unavailable")
+
+const (
+	retryAttempts  = 5
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 2 * time.Second
+)
+
+// Client provides access to GCE metadata using a caller-supplied
+// *http.Client, so that callers can plug in their own transport (for
+// instrumentation, proxies, etc.) and cancel in-flight requests via
+// context. The zero value is not usable; construct one with NewClient.
+type Client struct {
+	hc *http.Client
+
+	// subscribeHC is used for the long-polling requests issued by
+	// Subscribe. It defaults to a bare *http.Client rather than reusing
+	// hc: hc may carry a short ResponseHeaderTimeout (DefaultClient's
+	// does, so that off-GCE calls fail fast) which would abort every
+	// hanging-GET request before the metadata server had a chance to
+	// answer. A caller that needs its own instrumentation on the
+	// long-polling transport too can install it with
+	// WithSubscribeClient.
+	subscribeHC *http.Client
+
+	onGCE struct {
+		sync.Mutex
+		set bool
+		v   bool
+	}
+
+	tokensMu sync.Mutex
+	tokens   map[string]*Token
+}
+
+// NewClient returns a Client that issues metadata requests using hc.
+func NewClient(hc *http.Client) *Client {
+	return &Client{hc: hc, subscribeHC: &http.Client{}}
+}
+
+// WithSubscribeClient installs hc as the *http.Client used for Subscribe's
+// long-polling requests, replacing the bare client NewClient installs by
+// default, and returns c for chaining. hc must not set a short
+// ResponseHeaderTimeout (or overall Timeout): long-polling requests are
+// expected by design to hang open until the watched key changes.
+func (c *Client) WithSubscribeClient(hc *http.Client) *Client {
+	c.subscribeHC = hc
+	return c
+}
+
+// DefaultClient is the Client used by the package-level functions. Its
+// *http.Client uses short dial and response-header timeouts so that
+// metadata calls fail fast when not running on GCE.
+var DefaultClient = NewClient(&http.Client{
+	Transport: &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout:   2 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		ResponseHeaderTimeout: 2 * time.Second,
+	},
+})
+
+// metadataHost returns the host to send metadata requests to, honoring
+// GCE_METADATA_HOST so the metadata service can be spoofed in containers
+// during local testing.
+func metadataHost() string {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		// Using 169.254.169.254 instead of "metadata" here because Go
+		// binaries built with the "netgo" tag and without cgo won't
+		// know the search suffix for "metadata" is
+		// ".google.internal", and this IP address is documented as
+		// being stable anyway.
+		host = "169.254.169.254"
+	}
+	return host
+}
+
+// StatusError is returned by rawGet when the metadata server responds
+// with a status code other than 200 or 404.
+type StatusError struct {
+	Code int
+	URL  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code %d trying to fetch %s", e.Code, e.URL)
+}
+
+// rawGet issues a single metadata GET request for suffix (which may
+// already carry a query string) using hc, returning the body and the
+// response's Etag header.
+func rawGet(ctx context.Context, hc *http.Client, suffix string) (value, etag string, err error) {
+	u := "http://" + metadataHost() + "/computeMetadata/v1/" + suffix
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	res, err := hc.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return "", "", NotDefinedError(suffix)
+	}
+	if res.StatusCode != 200 {
+		return "", "", &StatusError{Code: res.StatusCode, URL: u}
+	}
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return string(all), res.Header.Get("Etag"), nil
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// a 5xx response from the metadata server, or any error that is not a
+// *StatusError (network and transport failures). Any other status (a 4xx
+// such as 403) is a real problem the caller needs to see, not something
+// that will fix itself on retry.
+func isRetryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code >= 500
+	}
+	return true
+}
+
+func (c *Client) doGet(ctx context.Context, suffix string) (value, etag string, err error) {
+	return rawGet(ctx, c.hc, suffix)
+}
+
+// getETagWithContext retries transient failures (network errors and 5xx
+// responses) with exponential backoff, since the metadata server can
+// briefly return those right after a VM boots. A 404 is returned
+// immediately as NotDefinedError, and any other non-retryable error
+// (e.g. a 403) is returned immediately as-is, since retrying won't fix a
+// permissions or configuration problem. If every attempt is exhausted,
+// the returned error wraps ErrMetadataUnavailable and includes the last
+// error's message rather than discarding it.
+func (c *Client) getETagWithContext(ctx context.Context, suffix string) (value, etag string, err error) {
+	delay := retryBaseDelay
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		value, etag, err = c.doGet(ctx, suffix)
+		if err == nil {
+			return value, etag, nil
+		}
+		if _, ok := err.(NotDefinedError); ok {
+			return "", "", err
+		}
+		if !isRetryable(err) {
+			return "", "", err
+		}
+		if attempt == retryAttempts-1 {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+	return "", "", fmt.Errorf("%w: %v", ErrMetadataUnavailable, err)
+}
+
+func (c *Client) getETag(suffix string) (value, etag string, err error) {
+	return c.getETagWithContext(context.Background(), suffix)
+}
+
+// GetWithContext returns a metadata value as a string, canceling the
+// request if ctx is done before it completes.
+func (c *Client) GetWithContext(ctx context.Context, suffix string) (string, error) {
+	val, _, err := c.getETagWithContext(ctx, suffix)
+	return val, err
+}
+
+// Get returns a metadata value as a string.
+//
+// If the GCE_METADATA_HOST environment variable is not empty, it is used
+// as the host instead of the default metadata host. This is useful for
+// testing or for requesting retrieval of GCE metadata from a custom
+// metadata server in a non-GCE environment.
+//
+// If the requested metadata is not defined, the returned error will be
+// of type NotDefinedError.
+func (c *Client) Get(suffix string) (string, error) {
+	return c.GetWithContext(context.Background(), suffix)
+}
+
+// Get calls DefaultClient.Get.
+func Get(suffix string) (string, error) {
+	return DefaultClient.Get(suffix)
+}
+
+// GetWithContext calls DefaultClient.GetWithContext.
+func GetWithContext(ctx context.Context, suffix string) (string, error) {
+	return DefaultClient.GetWithContext(ctx, suffix)
+}
+
+func (c *Client) getTrimmedWithContext(ctx context.Context, suffix string) (s string, err error) {
+	s, err = c.GetWithContext(ctx, suffix)
+	s = strings.TrimSpace(s)
+	return
+}
+
+func (c *Client) getTrimmed(suffix string) (s string, err error) {
+	return c.getTrimmedWithContext(context.Background(), suffix)
+}
+
+// subscribeDeletedRetryDelay is how long Subscribe waits before
+// re-polling after its key is deleted. A deleted key can't be long-polled
+// (the server has nothing to hang a wait_for_change request on and
+// answers 404 immediately), so without this delay Subscribe would spin,
+// hammering the metadata server with back-to-back requests until the key
+// reappears or fn returns an error.
+const subscribeDeletedRetryDelay = 5 * time.Second
+
+// SubscribeWithContext calls fn with the value of the metadata key at
+// suffix, and again every time that value changes, using the metadata
+// server's hanging-GET support (the documented wait_for_change/last_etag
+// query parameters). fn is first called with the key's current value and
+// ok set to true. SubscribeWithContext then blocks, issuing a new
+// long-polling request as soon as the previous one resolves, until fn
+// returns a non-nil error, ctx is done, or a long-polling request fails
+// with an error other than a 404; that error is then returned.
+//
+// If suffix is not defined, SubscribeWithContext returns NotDefinedError
+// without calling fn. If the key is later deleted, fn is called with
+// ("", false) instead of SubscribeWithContext returning an error.
+//
+// The long-polling requests are issued with c.subscribeHC rather than
+// c.hc; see the Client.subscribeHC field doc for why.
+func (c *Client) SubscribeWithContext(ctx context.Context, suffix string, fn func(value string, ok bool) error) error {
+	value, lastETag, err := c.getETagWithContext(ctx, suffix)
+	if err != nil {
+		return err
+	}
+	if err := fn(value, true); err != nil {
+		return err
+	}
+
+	separator := "?"
+	if strings.Contains(suffix, "?") {
+		separator = "&"
+	}
+
+	for {
+		pollSuffix := suffix + separator + "wait_for_change=true&last_etag=" + url.QueryEscape(lastETag)
+		v, etag, err := rawGet(ctx, c.subscribeHC, pollSuffix)
+		if err != nil {
+			if _, ok := err.(NotDefinedError); ok {
+				if err := fn("", false); err != nil {
+					return err
+				}
+				select {
+				case <-time.After(subscribeDeletedRetryDelay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+			return err
+		}
+		lastETag = etag
+		if err := fn(v, true); err != nil {
+			return err
+		}
+	}
+}
+
+// Subscribe calls fn with the value of the metadata key at suffix, and
+// again every time that value changes. See SubscribeWithContext for
+// details.
+func (c *Client) Subscribe(suffix string, fn func(value string, ok bool) error) error {
+	return c.SubscribeWithContext(context.Background(), suffix, fn)
+}
+
+// Subscribe calls DefaultClient.Subscribe.
+func Subscribe(suffix string, fn func(value string, ok bool) error) error {
+	return DefaultClient.Subscribe(suffix, fn)
+}
+
+// SubscribeWithContext calls DefaultClient.SubscribeWithContext.
+func SubscribeWithContext(ctx context.Context, suffix string, fn func(value string, ok bool) error) error {
+	return DefaultClient.SubscribeWithContext(ctx, suffix, fn)
+}
+
+// OnGCE reports whether this process is running on Google Compute Engine.
+func (c *Client) OnGCE() bool {
+	c.onGCE.Lock()
+	defer c.onGCE.Unlock()
+	if c.onGCE.set {
+		return c.onGCE.v
+	}
+	c.onGCE.set = true
+	c.onGCE.v = testOnGCE()
+	return c.onGCE.v
+}
+
+// testOnGCE races a DNS lookup of metadata.google.internal against an
+// HTTP probe of the metadata IP, each bounded by a 2-second timeout,
+// and reports true as soon as either succeeds. Resolving the
+// internal-only DNS name is itself a strong signal; the HTTP probe only
+// counts as success if the response carries Metadata-Flavor: Google, so
+// that a device on the network that merely happens to answer on that IP
+// doesn't produce a false positive.
+func testOnGCE() bool {
+	if os.Getenv("GCE_METADATA_HOST") != "" {
+		return true
+	}
+
+	result := make(chan bool, 2)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, err := net.DefaultResolver.LookupHost(ctx, "metadata.google.internal")
+		result <- err == nil
+	}()
+
+	go func() {
+		hc := &http.Client{
+			Transport: &http.Transport{
+				Dial:                  (&net.Dialer{Timeout: 2 * time.Second}).Dial,
+				ResponseHeaderTimeout: 2 * time.Second,
+			},
+		}
+		req, _ := http.NewRequest("GET", "http://169.254.169.254/", nil)
+		req.Header.Set("Metadata-Flavor", "Google")
+		res, err := hc.Do(req)
+		if err != nil {
+			result <- false
+			return
+		}
+		defer res.Body.Close()
+		result <- res.Header.Get("Metadata-Flavor") == "Google"
+	}()
+
+	for i := 0; i < 2; i++ {
+		if <-result {
+			return true
+		}
+	}
+	return false
+}
+
+// OnGCE calls DefaultClient.OnGCE.
+func OnGCE() bool {
+	return DefaultClient.OnGCE()
+}
+
+// ProjectIDWithContext returns the current instance's project ID string.
+func (c *Client) ProjectIDWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "project/project-id")
+}
+
+// ProjectID returns the current instance's project ID string.
+func (c *Client) ProjectID() (string, error) {
+	return c.ProjectIDWithContext(context.Background())
+}
+
+// ProjectID calls DefaultClient.ProjectID.
+func ProjectID() (string, error) { return DefaultClient.ProjectID() }
+
+// ProjectIDWithContext calls DefaultClient.ProjectIDWithContext.
+func ProjectIDWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.ProjectIDWithContext(ctx)
+}
+
+// NumericProjectIDWithContext returns the current instance's numeric
+// project ID.
+func (c *Client) NumericProjectIDWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "project/numeric-project-id")
+}
+
+// NumericProjectID returns the current instance's numeric project ID.
+func (c *Client) NumericProjectID() (string, error) {
+	return c.NumericProjectIDWithContext(context.Background())
+}
+
+// NumericProjectID calls DefaultClient.NumericProjectID.
+func NumericProjectID() (string, error) { return DefaultClient.NumericProjectID() }
+
+// NumericProjectIDWithContext calls DefaultClient.NumericProjectIDWithContext.
+func NumericProjectIDWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.NumericProjectIDWithContext(ctx)
+}
+
+// InternalIPWithContext returns the instance's primary internal IP address.
+func (c *Client) InternalIPWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "instance/network-interfaces/0/ip")
+}
+
+// InternalIP returns the instance's primary internal IP address.
+func (c *Client) InternalIP() (string, error) {
+	return c.InternalIPWithContext(context.Background())
+}
+
+// InternalIP calls DefaultClient.InternalIP.
+func InternalIP() (string, error) { return DefaultClient.InternalIP() }
+
+// InternalIPWithContext calls DefaultClient.InternalIPWithContext.
+func InternalIPWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.InternalIPWithContext(ctx)
+}
+
+// ExternalIPWithContext returns the instance's primary external (public)
+// IP address.
+func (c *Client) ExternalIPWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "instance/network-interfaces/0/access-configs/0/external-ip")
+}
+
+// ExternalIP returns the instance's primary external (public) IP address.
+func (c *Client) ExternalIP() (string, error) {
+	return c.ExternalIPWithContext(context.Background())
+}
+
+// ExternalIP calls DefaultClient.ExternalIP.
+func ExternalIP() (string, error) { return DefaultClient.ExternalIP() }
+
+// ExternalIPWithContext calls DefaultClient.ExternalIPWithContext.
+func ExternalIPWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.ExternalIPWithContext(ctx)
+}
+
+// HostnameWithContext returns the instance's hostname. This will be of
+// the form "<instanceID>.c.<projID>.internal".
+func (c *Client) HostnameWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "instance/hostname")
+}
+
+// Hostname returns the instance's hostname. This will be of the form
+// "<instanceID>.c.<projID>.internal".
+func (c *Client) Hostname() (string, error) {
+	return c.HostnameWithContext(context.Background())
+}
+
+// Hostname calls DefaultClient.Hostname.
+func Hostname() (string, error) { return DefaultClient.Hostname() }
+
+// HostnameWithContext calls DefaultClient.HostnameWithContext.
+func HostnameWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.HostnameWithContext(ctx)
+}
+
+// MachineTypeWithContext returns the instance's machine type.
+func (c *Client) MachineTypeWithContext(ctx context.Context) (string, error) {
+	machine, err := c.getTrimmedWithContext(ctx, "instance/machine-type")
+	// machine-type is of the form "projects/<projNum>/machineTypes/<machine-typeName>
+	if err != nil {
+		return "", err
+	}
+	return machine[strings.LastIndex(machine, "/")+1:], nil
+}
+
+// MachineType returns the instance's machine type.
+func (c *Client) MachineType() (string, error) {
+	return c.MachineTypeWithContext(context.Background())
+}
+
+// MachineType calls DefaultClient.MachineType.
+func MachineType() (string, error) { return DefaultClient.MachineType() }
+
+// MachineTypeWithContext calls DefaultClient.MachineTypeWithContext.
+func MachineTypeWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.MachineTypeWithContext(ctx)
+}
+
+// DescriptionWithContext returns the instance's description.
+func (c *Client) DescriptionWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "instance/description")
+}
+
+// Description returns the instance's description.
+func (c *Client) Description() (string, error) {
+	return c.DescriptionWithContext(context.Background())
+}
+
+// Description calls DefaultClient.Description.
+func Description() (string, error) { return DefaultClient.Description() }
+
+// DescriptionWithContext calls DefaultClient.DescriptionWithContext.
+func DescriptionWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.DescriptionWithContext(ctx)
+}
+
+// InstanceTagsWithContext returns the list of user-defined instance
+// tags, assigned when initially creating a GCE instance.
+func (c *Client) InstanceTagsWithContext(ctx context.Context) ([]string, error) {
+	var s []string
+	j, err := c.GetWithContext(ctx, "instance/tags")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(strings.NewReader(j)).Decode(&s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// InstanceTags returns the list of user-defined instance tags,
+// assigned when initially creating a GCE instance.
+func (c *Client) InstanceTags() ([]string, error) {
+	return c.InstanceTagsWithContext(context.Background())
+}
+
+// InstanceTags calls DefaultClient.InstanceTags.
+func InstanceTags() ([]string, error) { return DefaultClient.InstanceTags() }
+
+// InstanceTagsWithContext calls DefaultClient.InstanceTagsWithContext.
+func InstanceTagsWithContext(ctx context.Context) ([]string, error) {
+	return DefaultClient.InstanceTagsWithContext(ctx)
+}
+
+// InstanceIDWithContext returns the current VM's numeric instance ID.
+func (c *Client) InstanceIDWithContext(ctx context.Context) (string, error) {
+	return c.getTrimmedWithContext(ctx, "instance/id")
+}
+
+// InstanceID returns the current VM's numeric instance ID.
+func (c *Client) InstanceID() (string, error) {
+	return c.InstanceIDWithContext(context.Background())
+}
+
+// InstanceID calls DefaultClient.InstanceID.
+func InstanceID() (string, error) { return DefaultClient.InstanceID() }
+
+// InstanceIDWithContext calls DefaultClient.InstanceIDWithContext.
+func InstanceIDWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.InstanceIDWithContext(ctx)
+}
+
+// InstanceNameWithContext returns the current VM's instance ID string.
+func (c *Client) InstanceNameWithContext(ctx context.Context) (string, error) {
+	host, err := c.HostnameWithContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.Split(host, ".")[0], nil
+}
+
+// InstanceName returns the current VM's instance ID string.
+func (c *Client) InstanceName() (string, error) {
+	return c.InstanceNameWithContext(context.Background())
+}
+
+// InstanceName calls DefaultClient.InstanceName.
+func InstanceName() (string, error) { return DefaultClient.InstanceName() }
+
+// InstanceNameWithContext calls DefaultClient.InstanceNameWithContext.
+func InstanceNameWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.InstanceNameWithContext(ctx)
+}
+
+// ZoneWithContext returns the current VM's zone, such as "us-central1-b".
+func (c *Client) ZoneWithContext(ctx context.Context) (string, error) {
+	zone, err := c.getTrimmedWithContext(ctx, "instance/zone")
+	// zone is of the form "projects/<projNum>/zones/<zoneName>".
+	if err != nil {
+		return "", err
+	}
+	return zone[strings.LastIndex(zone, "/")+1:], nil
+}
+
+// Zone returns the current VM's zone, such as "us-central1-b".
+func (c *Client) Zone() (string, error) {
+	return c.ZoneWithContext(context.Background())
+}
+
+// Zone calls DefaultClient.Zone.
+func Zone() (string, error) { return DefaultClient.Zone() }
+
+// ZoneWithContext calls DefaultClient.ZoneWithContext.
+func ZoneWithContext(ctx context.Context) (string, error) {
+	return DefaultClient.ZoneWithContext(ctx)
+}
+
+func (c *Client) linesWithContext(ctx context.Context, suffix string) ([]string, error) {
+	j, err := c.GetWithContext(ctx, suffix)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.Split(strings.TrimSpace(j), "\n")
+	for i := range s {
+		s[i] = strings.TrimSpace(s[i])
+	}
+	return s, nil
+}
+
+// InstanceAttributesWithContext returns the list of user-defined
+// attributes, assigned when initially creating a GCE VM instance. The
+// value of an attribute can be obtained with InstanceAttributeValue.
+func (c *Client) InstanceAttributesWithContext(ctx context.Context) ([]string, error) {
+	return c.linesWithContext(ctx, "instance/attributes/")
+}
+
+// InstanceAttributes returns the list of user-defined attributes,
+// assigned when initially creating a GCE VM instance. The value of an
+// attribute can be obtained with InstanceAttributeValue.
+func (c *Client) InstanceAttributes() ([]string, error) {
+	return c.InstanceAttributesWithContext(context.Background())
+}
+
+// InstanceAttributes calls DefaultClient.InstanceAttributes.
+func InstanceAttributes() ([]string, error) { return DefaultClient.InstanceAttributes() }
+
+// InstanceAttributesWithContext calls DefaultClient.InstanceAttributesWithContext.
+func InstanceAttributesWithContext(ctx context.Context) ([]string, error) {
+	return DefaultClient.InstanceAttributesWithContext(ctx)
+}
+
+// InstanceAttributeValueWithContext returns the value of the provided VM
+// instance attribute.
+//
+// If the requested attribute is not defined, the returned error will
+// be of type NotDefinedError.
+//
+// InstanceAttributeValueWithContext may return ("", nil) if the
+// attribute was defined to be the empty string.
+func (c *Client) InstanceAttributeValueWithContext(ctx context.Context, attr string) (string, error) {
+	return c.GetWithContext(ctx, "instance/attributes/"+attr)
+}
+
+// InstanceAttributeValue returns the value of the provided VM
+// instance attribute.
+//
+// If the requested attribute is not defined, the returned error will
+// be of type NotDefinedError.
+//
+// InstanceAttributeValue may return ("", nil) if the attribute was
+// defined to be the empty string.
+func (c *Client) InstanceAttributeValue(attr string) (string, error) {
+	return c.InstanceAttributeValueWithContext(context.Background(), attr)
+}
+
+// InstanceAttributeValue calls DefaultClient.InstanceAttributeValue.
+func InstanceAttributeValue(attr string) (string, error) {
+	return DefaultClient.InstanceAttributeValue(attr)
+}
+
+// InstanceAttributeValueWithContext calls DefaultClient.InstanceAttributeValueWithContext.
+func InstanceAttributeValueWithContext(ctx context.Context, attr string) (string, error) {
+	return DefaultClient.InstanceAttributeValueWithContext(ctx, attr)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alxschwarz/gce-metadata/metadatatest"
+)
+
+var errStopSubscribe = errors.New("stop subscribe")
+
+func withMockMetadataServer(t *testing.T, fixtures map[string]string) *metadatatest.Server {
+	t.Helper()
+	s := metadatatest.NewServer(fixtures)
+	prev, hadPrev := os.LookupEnv("GCE_METADATA_HOST")
+	os.Setenv("GCE_METADATA_HOST", s.URL())
+	t.Cleanup(func() {
+		s.Close()
+		if hadPrev {
+			os.Setenv("GCE_METADATA_HOST", prev)
+		} else {
+			os.Unsetenv("GCE_METADATA_HOST")
+		}
+	})
+	return s
+}
+
+func TestSubscribeDeletePath(t *testing.T) {
+	s := withMockMetadataServer(t, map[string]string{"instance/attributes/foo": "bar"})
+	c := NewClient(&http.Client{})
+
+	type event struct {
+		value string
+		ok    bool
+	}
+	events := make(chan event, 10)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- c.Subscribe("instance/attributes/foo", func(value string, ok bool) error {
+			events <- event{value, ok}
+			if !ok {
+				return errStopSubscribe
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case e := <-events:
+		if !e.ok || e.value != "bar" {
+			t.Fatalf("first event = %+v, want {bar true}", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for initial Subscribe callback")
+	}
+
+	s.Delete("instance/attributes/foo")
+
+	select {
+	case e := <-events:
+		if e.ok || e.value != "" {
+			t.Fatalf("delete event = %+v, want {\"\" false}", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for delete Subscribe callback")
+	}
+
+	select {
+	case err := <-done:
+		if err != errStopSubscribe {
+			t.Fatalf("Subscribe returned %v, want errStopSubscribe", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for Subscribe to return")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// AccessConfig describes an external access configuration attached to a
+// network interface, such as a one-to-one NAT IP.
+type AccessConfig struct {
+	Type       string `json:"type"`
+	ExternalIP string `json:"externalIp"`
+}
+
+// NetworkInterface describes one of the instance's network interfaces.
+type NetworkInterface struct {
+	Network       string         `json:"network"`
+	IP            string         `json:"ip"`
+	Mac           string         `json:"mac"`
+	Subnetmask    string         `json:"subnetmask"`
+	Gateway       string         `json:"gateway"`
+	IPAliases     []string       `json:"ipAliases"`
+	AccessConfigs []AccessConfig `json:"accessConfigs"`
+	DNSServers    []string       `json:"dnsServers"`
+}
+
+// Disk describes one of the instance's attached disks.
+type Disk struct {
+	DeviceName string `json:"deviceName"`
+	Index      int    `json:"index"`
+	Interface  string `json:"interface"`
+	Mode       string `json:"mode"`
+	Type       string `json:"type"`
+}
+
+// Scheduling describes the instance's scheduling options.
+type Scheduling struct {
+	AutomaticRestart  bool   `json:"automaticRestart"`
+	OnHostMaintenance string `json:"onHostMaintenance"`
+	Preemptible       bool   `json:"preemptible"`
+}
+
+// ServiceAccount describes one of the instance's attached service
+// accounts, as found under Instance.ServiceAccounts.
+type ServiceAccount struct {
+	Aliases []string `json:"aliases"`
+	Email   string   `json:"email"`
+	Scopes  []string `json:"scopes"`
+}
+
+// Instance is the recursive JSON representation of the "instance/"
+// metadata subtree, as returned by InstanceInfo.
+type Instance struct {
+	Attributes        map[string]string         `json:"attributes"`
+	CPUPlatform       string                    `json:"cpuPlatform"`
+	Description       string                    `json:"description"`
+	Disks             []Disk                    `json:"disks"`
+	Hostname          string                    `json:"hostname"`
+	ID                int64                     `json:"id"`
+	Image             string                    `json:"image"`
+	MachineType       string                    `json:"machineType"`
+	Name              string                    `json:"name"`
+	NetworkInterfaces []NetworkInterface        `json:"networkInterfaces"`
+	Scheduling        Scheduling                `json:"scheduling"`
+	ServiceAccounts   map[string]ServiceAccount `json:"serviceAccounts"`
+	Tags              []string                  `json:"tags"`
+	Zone              string                    `json:"zone"`
+}
+
+// Project is the recursive JSON representation of the "project/"
+// metadata subtree, as returned by ProjectInfo.
+type Project struct {
+	Attributes       map[string]string `json:"attributes"`
+	NumericProjectID int64             `json:"numericProjectId"`
+	ProjectID        string            `json:"projectId"`
+}
+
+// GetJSONWithContext fetches suffix with the recursive=true&alt=json
+// query parameters appended and decodes the resulting JSON document
+// into out.
+func (c *Client) GetJSONWithContext(ctx context.Context, suffix string, out interface{}) error {
+	separator := "?"
+	if strings.Contains(suffix, "?") {
+		separator = "&"
+	}
+	j, err := c.GetWithContext(ctx, suffix+separator+"recursive=true&alt=json")
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(strings.NewReader(j)).Decode(out)
+}
+
+// GetJSON fetches suffix with the recursive=true&alt=json query
+// parameters appended and decodes the resulting JSON document into out.
+// This replaces issuing one HTTP call per field when a caller needs
+// several values from the same metadata subtree.
+func (c *Client) GetJSON(suffix string, out interface{}) error {
+	return c.GetJSONWithContext(context.Background(), suffix, out)
+}
+
+// GetJSON calls DefaultClient.GetJSON.
+func GetJSON(suffix string, out interface{}) error { return DefaultClient.GetJSON(suffix, out) }
+
+// GetJSONWithContext calls DefaultClient.GetJSONWithContext.
+func GetJSONWithContext(ctx context.Context, suffix string, out interface{}) error {
+	return DefaultClient.GetJSONWithContext(ctx, suffix, out)
+}
+
+// InstanceInfoWithContext returns the instance's metadata, fetched in a
+// single recursive JSON request.
+func (c *Client) InstanceInfoWithContext(ctx context.Context) (*Instance, error) {
+	var inst Instance
+	if err := c.GetJSONWithContext(ctx, "instance/", &inst); err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// InstanceInfo returns the instance's metadata, fetched in a single
+// recursive JSON request.
+func (c *Client) InstanceInfo() (*Instance, error) {
+	return c.InstanceInfoWithContext(context.Background())
+}
+
+// InstanceInfo calls DefaultClient.InstanceInfo.
+func InstanceInfo() (*Instance, error) { return DefaultClient.InstanceInfo() }
+
+// InstanceInfoWithContext calls DefaultClient.InstanceInfoWithContext.
+func InstanceInfoWithContext(ctx context.Context) (*Instance, error) {
+	return DefaultClient.InstanceInfoWithContext(ctx)
+}
+
+// ProjectInfoWithContext returns the project's metadata, fetched in a
+// single recursive JSON request.
+func (c *Client) ProjectInfoWithContext(ctx context.Context) (*Project, error) {
+	var proj Project
+	if err := c.GetJSONWithContext(ctx, "project/", &proj); err != nil {
+		return nil, err
+	}
+	return &proj, nil
+}
+
+// ProjectInfo returns the project's metadata, fetched in a single
+// recursive JSON request.
+func (c *Client) ProjectInfo() (*Project, error) {
+	return c.ProjectInfoWithContext(context.Background())
+}
+
+// ProjectInfo calls DefaultClient.ProjectInfo.
+func ProjectInfo() (*Project, error) { return DefaultClient.ProjectInfo() }
+
+// ProjectInfoWithContext calls DefaultClient.ProjectInfoWithContext.
+func ProjectInfoWithContext(ctx context.Context) (*Project, error) {
+	return DefaultClient.ProjectInfoWithContext(ctx)
+}
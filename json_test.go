@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestInstanceInfoAgainstMockServer(t *testing.T) {
+	withMockMetadataServer(t, map[string]string{
+		"instance/id":                           "123",
+		"instance/hostname":                     "test.c.proj.internal",
+		"instance/scheduling/automaticRestart":  "true",
+		"instance/scheduling/onHostMaintenance": "MIGRATE",
+		"instance/scheduling/preemptible":       "false",
+		"instance/attributes/replicas":          "3",
+	})
+	c := NewClient(&http.Client{})
+
+	inst, err := c.InstanceInfo()
+	if err != nil {
+		t.Fatalf("InstanceInfo: %v", err)
+	}
+	if inst.ID != 123 {
+		t.Errorf("ID = %d, want 123", inst.ID)
+	}
+	if !inst.Scheduling.AutomaticRestart {
+		t.Errorf("Scheduling.AutomaticRestart = false, want true")
+	}
+	if inst.Scheduling.Preemptible {
+		t.Errorf("Scheduling.Preemptible = true, want false")
+	}
+	if inst.Attributes["replicas"] != "3" {
+		t.Errorf(`Attributes["replicas"] = %q, want "3"`, inst.Attributes["replicas"])
+	}
+}
@@ -0,0 +1,241 @@
+// Package metadatatest provides an in-process fake of the GCE metadata
+// server, so that code built on gce-metadata can be unit tested without
+// a real GCE VM.
+package metadatatest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is an in-process fake of the /computeMetadata/v1/ tree served
+// by the real GCE metadata server.
+type Server struct {
+	ts *httptest.Server
+
+	mu      sync.Mutex
+	values  map[string]string
+	onGCE   bool
+	waiters map[string][]chan struct{}
+}
+
+// NewServer starts and returns a Server pre-populated with fixtures, a
+// map from metadata key suffix (e.g. "instance/id") to its value.
+func NewServer(fixtures map[string]string) *Server {
+	values := make(map[string]string, len(fixtures))
+	for k, v := range fixtures {
+		values[strings.Trim(k, "/")] = v
+	}
+
+	s := &Server{
+		values:  values,
+		onGCE:   true,
+		waiters: make(map[string][]chan struct{}),
+	}
+	s.ts = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the host:port the Server is listening on, suitable for
+// os.Setenv("GCE_METADATA_HOST", server.URL()).
+func (s *Server) URL() string {
+	return strings.TrimPrefix(s.ts.URL, "http://")
+}
+
+// Close shuts down the Server, releasing its resources.
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// SetOnGCE controls whether a GET of "/" (the probe used to detect
+// whether a process is running on GCE) reports Metadata-Flavor: Google.
+func (s *Server) SetOnGCE(onGCE bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onGCE = onGCE
+}
+
+// Set updates the value at suffix and wakes up any hanging GET
+// (wait_for_change=true) blocked on it.
+func (s *Server) Set(suffix, value string) {
+	suffix = strings.Trim(suffix, "/")
+
+	s.mu.Lock()
+	s.values[suffix] = value
+	waiters := s.waiters[suffix]
+	delete(s.waiters, suffix)
+	s.mu.Unlock()
+
+	for _, ch := range waiters {
+		close(ch)
+	}
+}
+
+// Delete removes the value at suffix and wakes up any hanging GET
+// (wait_for_change=true) blocked on it, so it can observe the key's
+// absence with a 404.
+func (s *Server) Delete(suffix string) {
+	suffix = strings.Trim(suffix, "/")
+
+	s.mu.Lock()
+	delete(s.values, suffix)
+	waiters := s.waiters[suffix]
+	delete(s.waiters, suffix)
+	s.mu.Unlock()
+
+	for _, ch := range waiters {
+		close(ch)
+	}
+}
+
+// SetAccessToken installs an OAuth2 access token fixture for
+// serviceAccount, in the shape returned by Client.AccessToken.
+func (s *Server) SetAccessToken(serviceAccount, accessToken string, expiresIn int) {
+	j, _ := json.Marshal(struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}{accessToken, expiresIn, "Bearer"})
+	s.Set(fmt.Sprintf("instance/service-accounts/%s/token", serviceAccount), string(j))
+}
+
+func etag(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func notDefined(w http.ResponseWriter, suffix string) {
+	http.Error(w, fmt.Sprintf("metadata: GCE metadata %q not defined", suffix), http.StatusNotFound)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		s.mu.Lock()
+		onGCE := s.onGCE
+		s.mu.Unlock()
+		if onGCE {
+			w.Header().Set("Metadata-Flavor", "Google")
+		}
+		return
+	}
+
+	if r.Header.Get("Metadata-Flavor") != "Google" {
+		http.Error(w, "metadata: missing Metadata-Flavor: Google header", http.StatusForbidden)
+		return
+	}
+
+	suffix := strings.Trim(strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/"), "/")
+
+	q := r.URL.Query()
+	switch {
+	case q.Get("recursive") == "true" && q.Get("alt") == "json":
+		s.serveRecursive(w, suffix)
+	case q.Get("wait_for_change") == "true":
+		s.serveWait(w, r, suffix, q.Get("last_etag"))
+	default:
+		s.serveValue(w, suffix)
+	}
+}
+
+func (s *Server) serveValue(w http.ResponseWriter, suffix string) {
+	s.mu.Lock()
+	value, ok := s.values[suffix]
+	s.mu.Unlock()
+	if !ok {
+		notDefined(w, suffix)
+		return
+	}
+	w.Header().Set("Etag", etag(value))
+	fmt.Fprint(w, value)
+}
+
+// serveWait implements the hanging-GET long-polling behavior: it blocks
+// until Set is called for suffix with a value whose etag differs from
+// lastETag, Delete removes suffix (answered with a 404), or the request
+// is canceled.
+func (s *Server) serveWait(w http.ResponseWriter, r *http.Request, suffix, lastETag string) {
+	s.mu.Lock()
+	value, ok := s.values[suffix]
+	if !ok {
+		s.mu.Unlock()
+		notDefined(w, suffix)
+		return
+	}
+	if etag(value) != lastETag {
+		s.mu.Unlock()
+		w.Header().Set("Etag", etag(value))
+		fmt.Fprint(w, value)
+		return
+	}
+
+	ch := make(chan struct{})
+	s.waiters[suffix] = append(s.waiters[suffix], ch)
+	s.mu.Unlock()
+
+	select {
+	case <-ch:
+	case <-r.Context().Done():
+		return
+	}
+
+	s.serveWait(w, r, suffix, lastETag)
+}
+
+// serveRecursive renders every fixture under prefix as a single nested
+// JSON document, the way the real metadata server does for
+// ?recursive=true&alt=json.
+func (s *Server) serveRecursive(w http.ResponseWriter, prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tree := make(map[string]interface{})
+	found := false
+	for k, v := range s.values {
+		if prefix != "" && !strings.HasPrefix(k, prefix+"/") {
+			continue
+		}
+		found = true
+		rel := strings.TrimPrefix(k, prefix+"/")
+		path := strings.Split(rel, "/")
+		// Custom attribute values are always opaque strings on the real
+		// metadata server, never JSON - so they must round-trip as
+		// strings here too, whatever they happen to look like.
+		raw := strings.Contains("/"+k, "/attributes/")
+		setTreeValue(tree, path, v, raw)
+	}
+	if !found {
+		notDefined(w, prefix)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+func setTreeValue(tree map[string]interface{}, path []string, value string, raw bool) {
+	if len(path) == 1 {
+		if raw {
+			tree[path[0]] = value
+			return
+		}
+		var decoded interface{}
+		if json.Unmarshal([]byte(value), &decoded) == nil {
+			tree[path[0]] = decoded
+		} else {
+			tree[path[0]] = value
+		}
+		return
+	}
+	next, ok := tree[path[0]].(map[string]interface{})
+	if !ok {
+		next = make(map[string]interface{})
+		tree[path[0]] = next
+	}
+	setTreeValue(next, path[1:], value, raw)
+}
@@ -0,0 +1,144 @@
+package metadatatest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRequest(t *testing.T, s *Server, suffix, query string) *http.Request {
+	t.Helper()
+	url := "http://" + s.URL() + "/computeMetadata/v1/" + suffix
+	if query != "" {
+		url += "?" + query
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return req
+}
+
+func doRequest(t *testing.T, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, string(body)
+}
+
+func TestServeValue(t *testing.T) {
+	s := NewServer(map[string]string{"instance/id": "123"})
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/id", "")
+	res, body := doRequest(t, req)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.StatusCode)
+	}
+	if body != "123" {
+		t.Fatalf("body = %q, want %q", body, "123")
+	}
+	if res.Header.Get("Etag") == "" {
+		t.Fatal("Etag header not set")
+	}
+}
+
+func TestServeValueMissingFlavorHeader(t *testing.T) {
+	s := NewServer(map[string]string{"instance/id": "123"})
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/id", "")
+	req.Header.Del("Metadata-Flavor")
+	res, _ := doRequest(t, req)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", res.StatusCode)
+	}
+}
+
+func TestServeValueNotFound(t *testing.T) {
+	s := NewServer(nil)
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/id", "")
+	res, _ := doRequest(t, req)
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", res.StatusCode)
+	}
+}
+
+func TestServeRecursiveAttributesStayStrings(t *testing.T) {
+	s := NewServer(map[string]string{
+		"instance/attributes/replicas": "3",
+		"instance/attributes/enabled":  "true",
+		"instance/id":                  "123",
+	})
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/", "recursive=true&alt=json")
+	res, body := doRequest(t, req)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.StatusCode)
+	}
+
+	const wantReplicas = `"replicas":"3"`
+	const wantEnabled = `"enabled":"true"`
+	if !strings.Contains(body, wantReplicas) {
+		t.Errorf("body = %q, want substring %q (attribute value kept as a string)", body, wantReplicas)
+	}
+	if !strings.Contains(body, wantEnabled) {
+		t.Errorf("body = %q, want substring %q (attribute value kept as a string)", body, wantEnabled)
+	}
+}
+
+func TestServeRecursiveNonAttributesStillTypeSniffed(t *testing.T) {
+	s := NewServer(map[string]string{"instance/id": "123"})
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/", "recursive=true&alt=json")
+	_, body := doRequest(t, req)
+
+	const want = `"id":123`
+	if !strings.Contains(body, want) {
+		t.Errorf("body = %q, want substring %q (numeric value sniffed as a number)", body, want)
+	}
+}
+
+func TestServeWaitWakesUpOnSet(t *testing.T) {
+	s := NewServer(map[string]string{"instance/attributes/foo": "bar"})
+	defer s.Close()
+
+	req := newRequest(t, s, "instance/attributes/foo", "wait_for_change=true&last_etag="+etag("bar"))
+
+	done := make(chan string, 1)
+	go func() {
+		_, body := doRequest(t, req)
+		done <- body
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("serveWait returned before Set was called")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Set("instance/attributes/foo", "baz")
+
+	select {
+	case body := <-done:
+		if body != "baz" {
+			t.Fatalf("body = %q, want %q", body, "baz")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serveWait did not wake up after Set")
+	}
+}
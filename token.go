@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// tokenEarlyExpiry is how long before a cached token's real expiry it is
+// treated as already expired, so callers are never handed a token that
+// is about to be rejected by whatever it's presented to.
+const tokenEarlyExpiry = 60 * time.Second
+
+// Token is an OAuth2 access token obtained from the metadata server.
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"-"`
+}
+
+func defaultServiceAccount(serviceAccount string) string {
+	if serviceAccount == "" {
+		return "default"
+	}
+	return serviceAccount
+}
+
+// ScopesWithContext returns the list of OAuth2 scopes granted to
+// serviceAccount. Passing "" for serviceAccount is equivalent to
+// passing "default".
+func (c *Client) ScopesWithContext(ctx context.Context, serviceAccount string) ([]string, error) {
+	suffix := "instance/service-accounts/" + defaultServiceAccount(serviceAccount) + "/scopes"
+	return c.linesWithContext(ctx, suffix)
+}
+
+// Scopes returns the list of OAuth2 scopes granted to serviceAccount.
+// Passing "" for serviceAccount is equivalent to passing "default".
+func (c *Client) Scopes(serviceAccount string) ([]string, error) {
+	return c.ScopesWithContext(context.Background(), serviceAccount)
+}
+
+// Scopes calls DefaultClient.Scopes.
+func Scopes(serviceAccount string) ([]string, error) { return DefaultClient.Scopes(serviceAccount) }
+
+// ScopesWithContext calls DefaultClient.ScopesWithContext.
+func ScopesWithContext(ctx context.Context, serviceAccount string) ([]string, error) {
+	return DefaultClient.ScopesWithContext(ctx, serviceAccount)
+}
+
+// EmailWithContext returns the email address of serviceAccount. Passing
+// "" for serviceAccount is equivalent to passing "default".
+func (c *Client) EmailWithContext(ctx context.Context, serviceAccount string) (string, error) {
+	suffix := "instance/service-accounts/" + defaultServiceAccount(serviceAccount) + "/email"
+	return c.getTrimmedWithContext(ctx, suffix)
+}
+
+// Email returns the email address of serviceAccount. Passing "" for
+// serviceAccount is equivalent to passing "default".
+func (c *Client) Email(serviceAccount string) (string, error) {
+	return c.EmailWithContext(context.Background(), serviceAccount)
+}
+
+// Email calls DefaultClient.Email.
+func Email(serviceAccount string) (string, error) { return DefaultClient.Email(serviceAccount) }
+
+// EmailWithContext calls DefaultClient.EmailWithContext.
+func EmailWithContext(ctx context.Context, serviceAccount string) (string, error) {
+	return DefaultClient.EmailWithContext(ctx, serviceAccount)
+}
+
+// AccessTokenWithContext returns an OAuth2 access token for
+// serviceAccount, fetching a new one from the metadata server only once
+// the previously cached token is within tokenEarlyExpiry of expiring.
+// Passing "" for serviceAccount is equivalent to passing "default".
+func (c *Client) AccessTokenWithContext(ctx context.Context, serviceAccount string) (*Token, error) {
+	serviceAccount = defaultServiceAccount(serviceAccount)
+
+	c.tokensMu.Lock()
+	if tok, ok := c.tokens[serviceAccount]; ok && time.Now().Before(tok.Expiry.Add(-tokenEarlyExpiry)) {
+		c.tokensMu.Unlock()
+		return tok, nil
+	}
+	c.tokensMu.Unlock()
+
+	j, err := c.GetWithContext(ctx, "instance/service-accounts/"+serviceAccount+"/token")
+	if err != nil {
+		return nil, err
+	}
+	var tok Token
+	if err := json.NewDecoder(strings.NewReader(j)).Decode(&tok); err != nil {
+		return nil, err
+	}
+	tok.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+
+	c.tokensMu.Lock()
+	if c.tokens == nil {
+		c.tokens = make(map[string]*Token)
+	}
+	c.tokens[serviceAccount] = &tok
+	c.tokensMu.Unlock()
+
+	return &tok, nil
+}
+
+// AccessToken returns an OAuth2 access token for serviceAccount, reusing
+// a cached token until it is close to expiring. Passing "" for
+// serviceAccount is equivalent to passing "default".
+func (c *Client) AccessToken(serviceAccount string) (*Token, error) {
+	return c.AccessTokenWithContext(context.Background(), serviceAccount)
+}
+
+// AccessToken calls DefaultClient.AccessToken.
+func AccessToken(serviceAccount string) (*Token, error) {
+	return DefaultClient.AccessToken(serviceAccount)
+}
+
+// AccessTokenWithContext calls DefaultClient.AccessTokenWithContext.
+func AccessTokenWithContext(ctx context.Context, serviceAccount string) (*Token, error) {
+	return DefaultClient.AccessTokenWithContext(ctx, serviceAccount)
+}
+
+// IDTokenWithContext returns an OIDC identity token for serviceAccount,
+// scoped to audience. format selects the level of detail in the token's
+// claims ("standard" or "full"); passing "" is equivalent to passing
+// "standard". Passing "" for serviceAccount is equivalent to passing
+// "default".
+func (c *Client) IDTokenWithContext(ctx context.Context, serviceAccount, audience, format string) (string, error) {
+	if format == "" {
+		format = "standard"
+	}
+	suffix := "instance/service-accounts/" + defaultServiceAccount(serviceAccount) +
+		"/identity?audience=" + url.QueryEscape(audience) + "&format=" + url.QueryEscape(format) + "&licenses=FALSE"
+	return c.getTrimmedWithContext(ctx, suffix)
+}
+
+// IDToken returns an OIDC identity token for serviceAccount, scoped to
+// audience. format selects the level of detail in the token's claims
+// ("standard" or "full"); passing "" is equivalent to passing
+// "standard". Passing "" for serviceAccount is equivalent to passing
+// "default".
+func (c *Client) IDToken(serviceAccount, audience, format string) (string, error) {
+	return c.IDTokenWithContext(context.Background(), serviceAccount, audience, format)
+}
+
+// IDToken calls DefaultClient.IDToken.
+func IDToken(serviceAccount, audience, format string) (string, error) {
+	return DefaultClient.IDToken(serviceAccount, audience, format)
+}
+
+// IDTokenWithContext calls DefaultClient.IDTokenWithContext.
+func IDTokenWithContext(ctx context.Context, serviceAccount, audience, format string) (string, error) {
+	return DefaultClient.IDTokenWithContext(ctx, serviceAccount, audience, format)
+}